@@ -0,0 +1,310 @@
+// Command zstd-train trains a zstd dictionary from a directory of JSON
+// sample files, for use with compression.NewZstdDictCompressor (or the
+// benchmark's "zstdD" algorithm via -zstd_dict).
+//
+// It samples up to -samples files, builds a suffix array (and its LCP array,
+// via Kasai's algorithm) over the concatenated corpus to score repeated
+// substrings by frequency*length, then greedily keeps the highest-scoring,
+// non-overlapping segments until -size bytes have been collected. That
+// content is handed to zstd.BuildDict, which produces a real zstd
+// dictionary: magic 0xEC30A437, a dictionary ID, the three entropy tables
+// (literals Huffman table, offset/match-length/literal-length FSE tables),
+// and the dictionary content itself.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	defaultDictSize = 112 * 1024 // 112 KiB, the zstd reference trainer's default target.
+	minSegmentLen   = 8
+	maxSegmentLen   = 4096
+	// minDictContentLen is zstd.BuildDict's hard minimum for History length
+	// (it refuses anything shorter); buildDictionaryContent falls back to
+	// raw corpus bytes rather than handing it less than this.
+	minDictContentLen = 8
+)
+
+func parseFlags() (inputDir, outputPath string, sampleCount, dictSize int) {
+	flag.StringVar(&inputDir, "input", "", "Directory of JSON sample files to train on")
+	flag.StringVar(&outputPath, "output", "dictionary.zstd-dict", "Path to write the trained dictionary to")
+	flag.IntVar(&sampleCount, "samples", 100, "Maximum number of sample files to train on")
+	flag.IntVar(&dictSize, "size", defaultDictSize, "Target dictionary size in bytes")
+	flag.Parse()
+	return
+}
+
+func main() {
+	inputDir, outputPath, sampleCount, dictSize := parseFlags()
+	if inputDir == "" {
+		fmt.Println("Error: -input is required")
+		os.Exit(1)
+	}
+
+	samples, err := loadSamples(inputDir, sampleCount)
+	if err != nil {
+		fmt.Printf("Error loading samples: %v\n", err)
+		os.Exit(1)
+	}
+	if len(samples) == 0 {
+		fmt.Println("Error: no JSON samples found in input directory")
+		os.Exit(1)
+	}
+
+	content, err := buildDictionaryContent(samples, dictSize)
+	if err != nil {
+		fmt.Printf("Error building dictionary content: %v\n", err)
+		os.Exit(1)
+	}
+
+	dict, err := zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       dictionaryID(inputDir),
+		Contents: samples,
+		History:  content,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	if err != nil {
+		fmt.Printf("Error building dictionary: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(outputPath, dict, 0644); err != nil {
+		fmt.Printf("Error writing dictionary file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Trained zstd dictionary from %d samples (%d bytes content): %d bytes written to %s\n",
+		len(samples), len(content), len(dict), outputPath)
+}
+
+// loadSamples reads up to maxSamples .json files from inputDir, in
+// alphabetical order so runs are reproducible.
+func loadSamples(inputDir string, maxSamples int) ([][]byte, error) {
+	entries, err := os.ReadDir(inputDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	if len(names) > maxSamples {
+		names = names[:maxSamples]
+	}
+
+	samples := make([][]byte, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(inputDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading sample %s: %w", name, err)
+		}
+		samples = append(samples, data)
+	}
+	return samples, nil
+}
+
+// dictionaryID derives a stable, non-zero dictionary ID from the input
+// directory so re-training against the same corpus reproduces the same ID.
+func dictionaryID(inputDir string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(inputDir))
+	id := h.Sum32()
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+// candidate is a scored, repeated substring of the training corpus:
+// corpus[start:start+length], seen roughly `score/length` times.
+type candidate struct {
+	start, length, score int
+}
+
+// intervals tracks the byte ranges of the corpus already claimed by a chosen
+// dictionary segment, so later candidates can be rejected for overlapping.
+type intervals [][2]int
+
+func (iv intervals) overlaps(start, end int) bool {
+	for _, r := range iv {
+		if start < r[1] && end > r[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// buildDictionaryContent concatenates samples, scores repeated substrings
+// across the combined corpus with a suffix array, and greedily keeps the
+// highest frequency*length, non-overlapping, single-sample segments until
+// targetSize bytes have been collected. Sparse corpora (a handful of small,
+// mostly-distinct samples) can yield no substring repeated for at least
+// minSegmentLen bytes; rather than hand zstd.BuildDict too little content to
+// build a dictionary at all, this falls back to the raw corpus tail, the way
+// the reference zstd trainer does for the same case.
+func buildDictionaryContent(samples [][]byte, targetSize int) ([]byte, error) {
+	corpus, bounds := concatSamples(samples)
+	sa := buildSuffixArray(corpus)
+	lcp := kasaiLCP(corpus, sa)
+	candidates := scoreCandidates(sa, lcp)
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var used intervals
+	var content []byte
+	for _, c := range candidates {
+		if len(content) >= targetSize {
+			break
+		}
+		end := c.start + c.length
+		if !withinSingleSample(bounds, c.start, end) {
+			continue
+		}
+		if used.overlaps(c.start, end) {
+			continue
+		}
+		used = append(used, [2]int{c.start, end})
+		content = append(content, corpus[c.start:end]...)
+	}
+
+	if len(content) < minDictContentLen {
+		content = rawCorpusFallback(corpus, bounds, targetSize)
+	}
+	if len(content) < minDictContentLen {
+		return nil, fmt.Errorf("corpus too small to build a dictionary (%d bytes, need at least %d)", len(content), minDictContentLen)
+	}
+	return content, nil
+}
+
+// rawCorpusFallback returns up to the last targetSize bytes of corpus,
+// excluding its final sample, for use as a fallback dictionary History when
+// no repeated substring scores well enough. Excluding the final sample
+// matters: zstd.BuildDict self-tests History by compressing each of Contents
+// against it, and a sample whose bytes are already entirely present in
+// History compresses to zero literals, which crashes the underlying
+// histogram code. Leaving at least one sample only partially (or not at all)
+// covered by History avoids that.
+func rawCorpusFallback(corpus []byte, bounds [][2]int, targetSize int) []byte {
+	tail := corpus
+	switch {
+	case len(bounds) > 1:
+		tail = corpus[:bounds[len(bounds)-1][0]]
+	case len(bounds) == 1:
+		// Only one sample: there's nothing else to exclude, so leave out
+		// its second half instead, which has the same effect.
+		tail = corpus[:len(corpus)/2]
+	}
+	if len(tail) > targetSize {
+		tail = tail[len(tail)-targetSize:]
+	}
+	return tail
+}
+
+// concatSamples joins samples into one corpus, separated by a NUL byte so
+// suffixes don't silently span two unrelated files, and records each
+// sample's [start, end) range within the corpus.
+func concatSamples(samples [][]byte) ([]byte, [][2]int) {
+	var corpus []byte
+	bounds := make([][2]int, 0, len(samples))
+	for _, sample := range samples {
+		start := len(corpus)
+		corpus = append(corpus, sample...)
+		bounds = append(bounds, [2]int{start, len(corpus)})
+		corpus = append(corpus, 0)
+	}
+	return corpus, bounds
+}
+
+func withinSingleSample(bounds [][2]int, start, end int) bool {
+	for _, b := range bounds {
+		if start >= b[0] && end <= b[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSuffixArray returns the order of corpus's suffixes sorted
+// lexicographically. It's a plain sort.Slice comparison rather than a linear
+// SA-IS construction, which is fine for the sample sizes this tool targets
+// but will not scale to huge corpora.
+func buildSuffixArray(corpus []byte) []int {
+	sa := make([]int, len(corpus))
+	for i := range sa {
+		sa[i] = i
+	}
+	sort.Slice(sa, func(i, j int) bool {
+		return bytes.Compare(corpus[sa[i]:], corpus[sa[j]:]) < 0
+	})
+	return sa
+}
+
+// kasaiLCP computes the longest-common-prefix array for sa in O(n) using
+// Kasai's algorithm: lcp[i] is the shared prefix length between the suffixes
+// at sa[i-1] and sa[i].
+func kasaiLCP(corpus []byte, sa []int) []int {
+	n := len(corpus)
+	rank := make([]int, n)
+	for i, s := range sa {
+		rank[s] = i
+	}
+
+	lcp := make([]int, n)
+	h := 0
+	for i := 0; i < n; i++ {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := sa[rank[i]-1]
+		for i+h < n && j+h < n && corpus[i+h] == corpus[j+h] {
+			h++
+		}
+		lcp[rank[i]] = h
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
+
+// scoreCandidates turns runs of adjacent suffixes that share a long-enough
+// prefix into scored candidate substrings: the longer the shared prefix and
+// the more suffixes share it, the more often that substring repeats in the
+// corpus, so score = occurrences * length.
+func scoreCandidates(sa, lcp []int) []candidate {
+	n := len(sa)
+	candidates := make([]candidate, 0, n)
+	for i := 1; i < n; i++ {
+		length := lcp[i]
+		if length < minSegmentLen {
+			continue
+		}
+		if length > maxSegmentLen {
+			length = maxSegmentLen
+		}
+		occurrences := 2
+		for j := i + 1; j < n && lcp[j] >= length; j++ {
+			occurrences++
+		}
+		candidates = append(candidates, candidate{
+			start:  sa[i],
+			length: length,
+			score:  occurrences * length,
+		})
+	}
+	return candidates
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TestBuildDictionaryContentFallsBackOnSparseCorpus guards against a
+// regression of two bugs this package used to hit on small, mostly-distinct
+// sample sets: zstd.BuildDict hard-failing on an empty/near-empty History,
+// and a naive raw-corpus fallback that handed it a History identical to all
+// of Contents, which crashes zstd.BuildDict's histogram code with a
+// division-by-zero panic.
+func TestBuildDictionaryContentFallsBackOnSparseCorpus(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples [][]byte
+	}{
+		{
+			name: "all-distinct samples",
+			samples: [][]byte{
+				[]byte(`{"id":1,"name":"alpha","value":42}`),
+				[]byte(`{"x":"unique-beta-payload","n":7}`),
+				[]byte(`{"z":true,"other":"gamma-distinct"}`),
+			},
+		},
+		{
+			name:    "single sample",
+			samples: [][]byte{[]byte(`{"only":"one-sample-here"}`)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := buildDictionaryContent(tc.samples, defaultDictSize)
+			if err != nil {
+				t.Fatalf("buildDictionaryContent: %v", err)
+			}
+			if len(content) < minDictContentLen {
+				t.Fatalf("content too short for zstd.BuildDict: %d bytes", len(content))
+			}
+
+			if _, err := zstd.BuildDict(zstd.BuildDictOptions{
+				ID:       1,
+				Contents: tc.samples,
+				History:  content,
+				Offsets:  [3]int{1, 4, 8},
+			}); err != nil {
+				t.Fatalf("zstd.BuildDict: %v", err)
+			}
+		})
+	}
+}
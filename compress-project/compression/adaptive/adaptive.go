@@ -0,0 +1,187 @@
+// Package adaptive picks a single (codec, level) pair for a dataset instead
+// of benchmarking every algorithm exhaustively, following the practice (seen
+// e.g. in buildkit's compression.Config) of letting the caller express a
+// policy rather than hard-coding a codec.
+package adaptive
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"compression-project/compression"
+)
+
+// Objective weighs compression ratio against compress/decompress throughput
+// when SelectBest scores a (codec, level) candidate. The three weights are
+// expected to sum to 1, though SelectBest does not enforce it.
+type Objective struct {
+	RatioWeight      float64
+	CompressWeight   float64
+	DecompressWeight float64
+}
+
+// DefaultObjective favors ratio over raw throughput: 0.5*ratio + 0.3*compress
+// speed + 0.2*decompress speed.
+var DefaultObjective = Objective{RatioWeight: 0.5, CompressWeight: 0.3, DecompressWeight: 0.2}
+
+// candidate is one (codec, level) pair SelectBest benchmarks.
+type candidate struct {
+	Type  compression.CompressionType
+	Level int
+}
+
+// candidates mirrors the base algorithms the benchmark harness exercises. It
+// excludes zstdD (needs a trained dictionary) and zstd-par (a wrapper, not a
+// base codec), neither of which is a meaningful standalone candidate here.
+var candidates = []candidate{
+	{compression.Gzip, 1}, {compression.Gzip, 2},
+	{compression.Zstd, 1}, {compression.Zstd, 2}, {compression.Zstd, 3}, {compression.Zstd, 4},
+	{compression.Lz4, 0}, {compression.Lz4, 9},
+	{compression.Snappy, 0},
+	{compression.Brotli, 2}, {compression.Brotli, 5}, {compression.Brotli, 8},
+	{compression.Bzip2, 1}, {compression.Bzip2, 6}, {compression.Bzip2, 9},
+}
+
+// sampleSubsetSize caps how many samples SelectBest actually compresses per
+// candidate; running every candidate against the full dataset would defeat
+// the point of a cheap selection pass.
+const sampleSubsetSize = 8
+
+type measurement struct {
+	candidate      candidate
+	ratio          float64
+	compressMBps   float64
+	decompressMBps float64
+}
+
+// SelectBest benchmarks a small random subset of samples against every
+// (codec, level) candidate, normalizes ratio/compress-speed/decompress-speed
+// to 0-1 across the candidates, and returns the CompressionType and level
+// with the highest weighted score under objective.
+func SelectBest(samples [][]byte, objective Objective) (compression.CompressionType, int, error) {
+	if len(samples) == 0 {
+		return "", 0, fmt.Errorf("adaptive: no samples provided")
+	}
+
+	subset := sampleSubset(samples, sampleSubsetSize)
+
+	measurements := make([]measurement, 0, len(candidates))
+	for _, c := range candidates {
+		m, err := measure(c, subset)
+		if err != nil {
+			// A candidate that fails on this dataset just drops out of
+			// consideration rather than failing the whole selection.
+			continue
+		}
+		measurements = append(measurements, m)
+	}
+	if len(measurements) == 0 {
+		return "", 0, fmt.Errorf("adaptive: no candidate could be benchmarked")
+	}
+
+	best := argmaxScore(measurements, objective)
+	return best.candidate.Type, best.candidate.Level, nil
+}
+
+func sampleSubset(samples [][]byte, n int) [][]byte {
+	if len(samples) <= n {
+		return samples
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	indices := r.Perm(len(samples))[:n]
+	subset := make([][]byte, n)
+	for i, idx := range indices {
+		subset[i] = samples[idx]
+	}
+	return subset
+}
+
+func measure(c candidate, samples [][]byte) (measurement, error) {
+	compressor, err := compression.CompressorFactory(c.Type, c.Level, nil)
+	if err != nil {
+		return measurement{}, err
+	}
+
+	var originalSize, compressedSize int64
+	var compressTime, decompressTime time.Duration
+	for _, sample := range samples {
+		start := time.Now()
+		compressed, err := compressor.Compress(sample)
+		if err != nil {
+			return measurement{}, err
+		}
+		compressTime += time.Since(start)
+
+		start = time.Now()
+		if _, err := compressor.Decompress(compressed); err != nil {
+			return measurement{}, err
+		}
+		decompressTime += time.Since(start)
+
+		originalSize += int64(len(sample))
+		compressedSize += int64(len(compressed))
+	}
+	if compressedSize == 0 {
+		return measurement{}, fmt.Errorf("adaptive: %s level %d produced empty output", c.Type, c.Level)
+	}
+
+	return measurement{
+		candidate:      c,
+		ratio:          float64(originalSize) / float64(compressedSize),
+		compressMBps:   throughputMBps(originalSize, compressTime),
+		decompressMBps: throughputMBps(originalSize, decompressTime),
+	}, nil
+}
+
+func throughputMBps(size int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(size) / d.Seconds() / (1 << 20)
+}
+
+func argmaxScore(measurements []measurement, objective Objective) measurement {
+	normRatio := normalize(measurements, func(m measurement) float64 { return m.ratio })
+	normCompress := normalize(measurements, func(m measurement) float64 { return m.compressMBps })
+	normDecompress := normalize(measurements, func(m measurement) float64 { return m.decompressMBps })
+
+	best := measurements[0]
+	bestScore := -1.0
+	for i, m := range measurements {
+		score := objective.RatioWeight*normRatio[i] +
+			objective.CompressWeight*normCompress[i] +
+			objective.DecompressWeight*normDecompress[i]
+		if score > bestScore {
+			bestScore = score
+			best = m
+		}
+	}
+	return best
+}
+
+// normalize min-max scales the metric get extracts from each measurement
+// into [0, 1] across the whole set. A set where every value is equal
+// normalizes to 1 for all (no candidate is penalized for an unvarying
+// metric).
+func normalize(measurements []measurement, get func(measurement) float64) []float64 {
+	lo, hi := get(measurements[0]), get(measurements[0])
+	for _, m := range measurements[1:] {
+		v := get(m)
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	out := make([]float64, len(measurements))
+	for i, m := range measurements {
+		if hi == lo {
+			out[i] = 1
+			continue
+		}
+		out[i] = (get(m) - lo) / (hi - lo)
+	}
+	return out
+}
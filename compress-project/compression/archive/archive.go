@@ -0,0 +1,164 @@
+// Package archive streams a directory through tar and a compression.Compressor
+// as a single unit, the codec-agnostic equivalent of the .tar.gz / .tar.bz2
+// pattern: compressing a tarball instead of each file individually lets the
+// codec's sliding window reuse strings across related files.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"compression-project/compression"
+)
+
+// CompressDir tars every regular file under dir (paths relative to dir) and
+// compresses the result with c, writing the compressed bytes to w. If c
+// implements compression.StreamingCompressor, the tar stream is piped
+// straight into CompressStream so the tarball is never held in memory in
+// full; otherwise the tarball is built in memory and compressed as a whole.
+func CompressDir(dir string, c compression.Compressor, w io.Writer) error {
+	if sc, ok := c.(compression.StreamingCompressor); ok {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeTar(dir, pw))
+		}()
+		if err := sc.CompressStream(w, pr); err != nil {
+			return fmt.Errorf("archive: streaming tar for %s: %w", dir, err)
+		}
+		return nil
+	}
+
+	var tarBuf bytes.Buffer
+	if err := writeTar(dir, &tarBuf); err != nil {
+		return fmt.Errorf("archive: building tar for %s: %w", dir, err)
+	}
+	compressed, err := c.Compress(tarBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("archive: compressing tar: %w", err)
+	}
+	_, err = w.Write(compressed)
+	return err
+}
+
+// writeTar tars every regular file under dir (paths relative to dir) into w.
+func writeTar(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// DecompressDir decompresses r with c and extracts the resulting tar archive
+// into outDir, recreating each entry's path relative to outDir. If c
+// implements compression.StreamingCompressor, decompression is piped
+// straight into the tar reader so the archive is never held in memory in
+// full; otherwise it's decompressed into memory and then extracted.
+func DecompressDir(r io.Reader, c compression.Compressor, outDir string) error {
+	if sc, ok := c.(compression.StreamingCompressor); ok {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(sc.DecompressStream(pw, r))
+		}()
+		return extractTar(pr, outDir)
+	}
+
+	compressed, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("archive: reading compressed archive: %w", err)
+	}
+	tarData, err := c.Decompress(compressed)
+	if err != nil {
+		return fmt.Errorf("archive: decompressing archive: %w", err)
+	}
+	return extractTar(bytes.NewReader(tarData), outDir)
+}
+
+// extractTar reads a tar stream from r and recreates it under outDir.
+func extractTar(r io.Reader, outDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("archive: reading tar entry: %w", err)
+		}
+
+		target, err := safeJoin(outDir, header.Name)
+		if err != nil {
+			return fmt.Errorf("archive: %w", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeTarFile(target, header, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// safeJoin joins outDir and name the way tar extraction needs to: it resolves
+// the result and rejects any name (e.g. "../../etc/passwd") that would land
+// outside outDir, the classic tar-slip / path traversal defense (CWE-22). An
+// absolute name is not rejected: filepath.Join places it under outDir.
+func safeJoin(outDir, name string) (string, error) {
+	target := filepath.Join(outDir, name)
+	cleanOutDir := filepath.Clean(outDir)
+	if target != cleanOutDir && !strings.HasPrefix(target, cleanOutDir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("tar entry %q escapes output directory", name)
+	}
+	return target, nil
+}
+
+func writeTarFile(target string, header *tar.Header, tr *tar.Reader) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, tr)
+	return err
+}
@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"compression-project/compression"
+)
+
+// TestDecompressDirRejectsEscapingEntry guards against a regression of the
+// tar-slip bug (CWE-22) that safeJoin fixes: a tar entry whose name would
+// resolve outside outDir, via "../" traversal or an absolute path, must
+// never be written there.
+func TestDecompressDirRejectsEscapingEntry(t *testing.T) {
+	c, err := compression.NewZstdCompressor(2)
+	if err != nil {
+		t.Fatalf("NewZstdCompressor: %v", err)
+	}
+
+	t.Run("relative traversal", func(t *testing.T) {
+		outDir := t.TempDir()
+		escapeTarget := filepath.Join(filepath.Dir(outDir), "escaped-via-traversal.txt")
+		os.Remove(escapeTarget)
+
+		compressed := buildMaliciousArchive(t, c, "../escaped-via-traversal.txt", "pwned")
+		if err := DecompressDir(bytes.NewReader(compressed), c, outDir); err == nil {
+			t.Fatal("DecompressDir did not reject a \"../\"-escaping tar entry")
+		}
+		if _, err := os.Stat(escapeTarget); err == nil {
+			t.Fatalf("tar entry escaped outDir to %s", escapeTarget)
+		}
+	})
+
+	t.Run("absolute path", func(t *testing.T) {
+		// filepath.Join(outDir, name) already neutralizes an absolute name
+		// by treating it as just another path element, so this should
+		// extract harmlessly inside outDir rather than error.
+		outDir := t.TempDir()
+		absoluteName := filepath.Join(t.TempDir(), "escaped-via-absolute.txt")
+
+		compressed := buildMaliciousArchive(t, c, absoluteName, "pwned")
+		if err := DecompressDir(bytes.NewReader(compressed), c, outDir); err != nil {
+			t.Fatalf("DecompressDir rejected a safely-containable absolute-path entry: %v", err)
+		}
+		if _, err := os.Stat(absoluteName); err == nil {
+			t.Fatalf("tar entry escaped outDir to the literal absolute path %s", absoluteName)
+		}
+	})
+}
+
+// buildMaliciousArchive compresses a tar archive containing a single entry
+// named name with the given content, using c.
+func buildMaliciousArchive(t *testing.T, c compression.Compressor, name, content string) []byte {
+	t.Helper()
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("tar Write: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close: %v", err)
+	}
+	compressed, err := c.Compress(tarBuf.Bytes())
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	return compressed
+}
@@ -2,10 +2,12 @@ package compression
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/andybalholm/brotli"
 	"github.com/dsnet/compress/bzip2"
@@ -21,21 +23,53 @@ type Compressor interface {
 	Decompress(data []byte) ([]byte, error)
 }
 
+// StreamingCompressor is implemented by Compressors that can read/write
+// through an io.Reader/io.Writer instead of buffering a whole payload in
+// memory, borrowing their encoder/decoder from a pool keyed on compression
+// level rather than allocating one per call.
+type StreamingCompressor interface {
+	CompressStream(dst io.Writer, src io.Reader) error
+	DecompressStream(dst io.Writer, src io.Reader) error
+	// Reset returns any encoder/decoder c is still holding onto back to the
+	// pool it came from. CompressStream and DecompressStream already check
+	// theirs back in after each call, so Reset is only needed if a caller
+	// keeps a StreamingCompressor around without using it for a while.
+	Reset()
+}
+
+// loadOrStorePool returns the *sync.Pool registered for key in m, creating
+// one with newFn if this is the first use of that key. Safe for concurrent
+// use by the parallel compression path.
+func loadOrStorePool(m *sync.Map, key int, newFn func() interface{}) *sync.Pool {
+	if v, ok := m.Load(key); ok {
+		return v.(*sync.Pool)
+	}
+	actual, _ := m.LoadOrStore(key, &sync.Pool{New: newFn})
+	return actual.(*sync.Pool)
+}
+
 // CompressionType 定义了支持的压缩算法类型
 type CompressionType string
 
 const (
-	Gzip   CompressionType = "gzip"
-	Xz     CompressionType = "xz"
-	Zstd   CompressionType = "zstd"
-	Lz4    CompressionType = "lz4"
-	Snappy CompressionType = "snappy"
-	Brotli CompressionType = "brotli"
-	Bzip2  CompressionType = "bzip2"
+	Gzip         CompressionType = "gzip"
+	Xz           CompressionType = "xz"
+	Zstd         CompressionType = "zstd"
+	ZstdDict     CompressionType = "zstdD"
+	ZstdParallel CompressionType = "zstd-par"
+	Lz4          CompressionType = "lz4"
+	Snappy       CompressionType = "snappy"
+	Brotli       CompressionType = "brotli"
+	Bzip2        CompressionType = "bzip2"
+	Deflate      CompressionType = "deflate"
 )
 
 // CompressorFactory 工厂函数，根据算法名称和压缩级别返回对应的 Compressor
-func CompressorFactory(compressionType CompressionType, level int) (Compressor, error) {
+//
+// dicts carries any dictionaries loaded by the caller (e.g. via -zstd_dict),
+// keyed by the CompressionType they apply to. It may be nil for callers that
+// never use dictionary-mode compressors.
+func CompressorFactory(compressionType CompressionType, level int, dicts map[CompressionType][]byte) (Compressor, error) {
 	switch compressionType {
 	case Gzip:
 		return NewGzipCompressor(level)
@@ -43,6 +77,18 @@ func CompressorFactory(compressionType CompressionType, level int) (Compressor,
 		return NewXzCompressor()
 	case Zstd:
 		return NewZstdCompressor(level)
+	case ZstdDict:
+		dict := dicts[Zstd]
+		if len(dict) == 0 {
+			return nil, errors.New("zstdD compressor requires a zstd dictionary (see -zstd_dict)")
+		}
+		return NewZstdDictCompressor(level, dict)
+	case ZstdParallel:
+		inner, err := NewZstdCompressor(level)
+		if err != nil {
+			return nil, err
+		}
+		return NewParallelCompressor(inner, 0, 0)
 	case Lz4:
 		return NewLz4Compressor(level)
 	case Snappy:
@@ -51,6 +97,8 @@ func CompressorFactory(compressionType CompressionType, level int) (Compressor,
 		return NewBrotliCompressor(level)
 	case Bzip2:
 		return NewBzip2Compressor(level)
+	case Deflate:
+		return NewDeflateCompressor(level)
 	default:
 		return nil, errors.New("unsupported compression type")
 	}
@@ -105,6 +153,92 @@ func (c *GzipCompressor) Decompress(data []byte) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
+var gzipWriterPools sync.Map // int (level) -> *sync.Pool of *gzip.Writer
+
+func (c *GzipCompressor) CompressStream(dst io.Writer, src io.Reader) error {
+	pool := loadOrStorePool(&gzipWriterPools, c.level, func() interface{} {
+		writer, err := gzip.NewWriterLevel(io.Discard, c.mappingLevel())
+		if err != nil {
+			panic(err)
+		}
+		return writer
+	})
+	writer := pool.Get().(*gzip.Writer)
+	defer pool.Put(writer)
+	writer.Reset(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+var gzipReaderPool = sync.Pool{
+	New: func() interface{} { return new(gzip.Reader) },
+}
+
+func (c *GzipCompressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader := gzipReaderPool.Get().(*gzip.Reader)
+	defer gzipReaderPool.Put(reader)
+	if err := reader.Reset(src); err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err := io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for GzipCompressor: CompressStream already checks its
+// pooled writer back in after each call.
+func (c *GzipCompressor) Reset() {}
+
+// DeflateCompressor 实现了原始 DEFLATE 压缩算法（不带 gzip 头），
+// 主要供 HTTP "deflate" Content-Encoding 使用。
+type DeflateCompressor struct {
+	level int
+}
+
+func NewDeflateCompressor(level int) (*DeflateCompressor, error) {
+	if level < 1 || level > 4 {
+		return nil, errors.New("invalid deflate compression level")
+	}
+	return &DeflateCompressor{level: level}, nil
+}
+
+func (c *DeflateCompressor) mappingLevel() int {
+	switch c.level {
+	case 1:
+		return flate.BestSpeed
+	case 2:
+		return flate.DefaultCompression
+	case 3:
+		return flate.BestCompression
+	case 4:
+		return flate.HuffmanOnly
+	}
+	return flate.DefaultCompression
+}
+
+func (c *DeflateCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, c.mappingLevel())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	writer.Close()
+	return buf.Bytes(), nil
+}
+
+func (c *DeflateCompressor) Decompress(data []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
 // SnappyCompressor 实现了 Snappy 压缩算法
 type SnappyCompressor struct{}
 
@@ -120,6 +254,42 @@ func (c *SnappyCompressor) Decompress(data []byte) ([]byte, error) {
 	return snappy.Decode(nil, data)
 }
 
+var (
+	snappyWriterPool = sync.Pool{
+		New: func() interface{} { return snappy.NewBufferedWriter(io.Discard) },
+	}
+	snappyReaderPool = sync.Pool{
+		New: func() interface{} { return snappy.NewReader(nil) },
+	}
+)
+
+// CompressStream writes src to dst using the Snappy framing format, via a
+// pooled *snappy.Writer.
+func (c *SnappyCompressor) CompressStream(dst io.Writer, src io.Reader) error {
+	writer := snappyWriterPool.Get().(*snappy.Writer)
+	defer snappyWriterPool.Put(writer)
+	writer.Reset(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// DecompressStream reads the Snappy framing format from src, via a pooled
+// *snappy.Reader.
+func (c *SnappyCompressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader := snappyReaderPool.Get().(*snappy.Reader)
+	defer snappyReaderPool.Put(reader)
+	reader.Reset(src)
+	_, err := io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for SnappyCompressor: CompressStream already checks its
+// pooled writer back in after each call.
+func (c *SnappyCompressor) Reset() {}
+
 // XzCompressor 实现了 xz 压缩算法
 type XzCompressor struct{}
 
@@ -151,6 +321,34 @@ func (c *XzCompressor) Decompress(data []byte) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
+// CompressStream compresses src into dst. xz.Writer has no Reset method, so
+// unlike the other codecs this allocates a fresh writer per call instead of
+// pooling one.
+func (c *XzCompressor) CompressStream(dst io.Writer, src io.Reader) error {
+	writer, err := xz.NewWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// DecompressStream decompresses src into dst.
+func (c *XzCompressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader, err := xz.NewReader(src)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for XzCompressor: it holds no pooled state.
+func (c *XzCompressor) Reset() {}
+
 // ZstdCompressor 实现了 zstd 压缩算法
 type ZstdCompressor struct {
 	level int // 1-5
@@ -196,6 +394,125 @@ func (c *ZstdCompressor) Decompress(data []byte) ([]byte, error) {
 	return decoder.DecodeAll(data, nil)
 }
 
+// zstdEncoderPools holds one sync.Pool of *zstd.Encoder per zstd.EncoderLevel
+// (mirrors the fasthttp zstd pool pattern). The pools are created at package
+// init; the encoders are not: a pool's New calls zstd.NewWriter on the first
+// CompressStream at that level and whenever the pool is empty, and encoders
+// put back are reused after a Reset.
+var zstdEncoderPools = map[zstd.EncoderLevel]*sync.Pool{}
+
+// zstdDecoderPool mirrors zstdEncoderPools on the decode side; zstd decoders
+// aren't configured per level, so a single pool covers every ZstdCompressor.
+var zstdDecoderPool = &sync.Pool{
+	New: func() interface{} {
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			panic(err)
+		}
+		return decoder
+	},
+}
+
+func init() {
+	for _, level := range []zstd.EncoderLevel{
+		zstd.SpeedFastest,
+		zstd.SpeedDefault,
+		zstd.SpeedBetterCompression,
+		zstd.SpeedBestCompression,
+	} {
+		level := level
+		zstdEncoderPools[level] = &sync.Pool{
+			New: func() interface{} {
+				encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+				if err != nil {
+					panic(err)
+				}
+				return encoder
+			},
+		}
+	}
+}
+
+// CompressStream compresses src into dst using a *zstd.Encoder borrowed from
+// zstdEncoderPools and reset onto dst, returning it to the pool afterwards.
+func (c *ZstdCompressor) CompressStream(dst io.Writer, src io.Reader) error {
+	pool := zstdEncoderPools[c.mappingLevel()]
+	encoder := pool.Get().(*zstd.Encoder)
+	defer pool.Put(encoder)
+	encoder.Reset(dst)
+	if _, err := io.Copy(encoder, src); err != nil {
+		encoder.Close()
+		return err
+	}
+	return encoder.Close()
+}
+
+// DecompressStream decompresses src into dst using a *zstd.Decoder borrowed
+// from zstdDecoderPool and reset onto src.
+func (c *ZstdCompressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	decoder := zstdDecoderPool.Get().(*zstd.Decoder)
+	defer zstdDecoderPool.Put(decoder)
+	if err := decoder.Reset(src); err != nil {
+		return err
+	}
+	_, err := io.Copy(dst, decoder)
+	return err
+}
+
+// Reset is a no-op for ZstdCompressor: CompressStream and DecompressStream
+// already check their encoder/decoder back into the pool after each call.
+func (c *ZstdCompressor) Reset() {}
+
+// ZstdDictCompressor 实现了使用预训练字典的 zstd 压缩算法，
+// 适合大量结构相似的小文件（参见 cmd/zstd-train）。
+type ZstdDictCompressor struct {
+	level int // 1-4
+	dict  []byte
+}
+
+func NewZstdDictCompressor(level int, dict []byte) (*ZstdDictCompressor, error) {
+	if level < 1 || level > 4 {
+		return nil, errors.New("invalid zstd compression level")
+	}
+	if len(dict) == 0 {
+		return nil, errors.New("zstd dictionary must not be empty")
+	}
+	return &ZstdDictCompressor{level: level, dict: dict}, nil
+}
+
+func (c *ZstdDictCompressor) mappingLevel() zstd.EncoderLevel {
+	var zstdLevel zstd.EncoderLevel
+	switch c.level {
+	case 1:
+		zstdLevel = zstd.SpeedFastest
+	case 2:
+		zstdLevel = zstd.SpeedDefault
+	case 3:
+		zstdLevel = zstd.SpeedBetterCompression
+	case 4:
+		zstdLevel = zstd.SpeedBestCompression
+	}
+	return zstdLevel
+}
+
+func (c *ZstdDictCompressor) Compress(data []byte) ([]byte, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(c.mappingLevel()), zstd.WithEncoderDict(c.dict))
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(data, nil), nil
+}
+
+func (c *ZstdDictCompressor) Decompress(data []byte) ([]byte, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(c.dict))
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return decoder.DecodeAll(data, nil)
+}
+
 // BrotliCompressor 实现了 Brotli 压缩算法
 type BrotliCompressor struct {
 	level int
@@ -229,6 +546,32 @@ func (c *BrotliCompressor) Decompress(data []byte) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
+var brotliWriterPools sync.Map // int (level) -> *sync.Pool of *brotli.Writer
+
+func (c *BrotliCompressor) CompressStream(dst io.Writer, src io.Reader) error {
+	pool := loadOrStorePool(&brotliWriterPools, c.level, func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, c.level-1)
+	})
+	writer := pool.Get().(*brotli.Writer)
+	defer pool.Put(writer)
+	writer.Reset(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (c *BrotliCompressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader := brotli.NewReader(src)
+	_, err := io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for BrotliCompressor: CompressStream already checks its
+// pooled writer back in after each call.
+func (c *BrotliCompressor) Reset() {}
+
 // Bzip2Compressor 实现了 bzip2 压缩算法
 type Bzip2Compressor struct {
 	level int
@@ -268,6 +611,53 @@ func (c *Bzip2Compressor) Decompress(data []byte) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
+var bzip2WriterPools sync.Map // int (level) -> *sync.Pool of *bzip2.Writer
+
+func (c *Bzip2Compressor) CompressStream(dst io.Writer, src io.Reader) error {
+	pool := loadOrStorePool(&bzip2WriterPools, c.level, func() interface{} {
+		writer, err := bzip2.NewWriter(io.Discard, &bzip2.WriterConfig{Level: c.level})
+		if err != nil {
+			panic(err)
+		}
+		return writer
+	})
+	writer := pool.Get().(*bzip2.Writer)
+	defer pool.Put(writer)
+	if err := writer.Reset(dst); err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+var bzip2ReaderPool = sync.Pool{
+	New: func() interface{} {
+		reader, err := bzip2.NewReader(bytes.NewReader(nil), nil)
+		if err != nil {
+			panic(err)
+		}
+		return reader
+	},
+}
+
+func (c *Bzip2Compressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader := bzip2ReaderPool.Get().(*bzip2.Reader)
+	defer bzip2ReaderPool.Put(reader)
+	if err := reader.Reset(src); err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err := io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for Bzip2Compressor: CompressStream already checks its
+// pooled writer back in after each call.
+func (c *Bzip2Compressor) Reset() {}
+
 // Lz4Compressor implements the LZ4 compression algorithm with support for compression levels 0-9.
 type Lz4Compressor struct {
 	level int
@@ -343,3 +733,42 @@ func (c *Lz4Compressor) Decompress(data []byte) ([]byte, error) {
 	reader := lz4.NewReader(bytes.NewReader(data))
 	return io.ReadAll(reader)
 }
+
+var lz4WriterPools sync.Map // int (level) -> *sync.Pool of *lz4.Writer
+
+// CompressStream compresses src into dst using a *lz4.Writer borrowed from
+// the pool for this level.
+func (c *Lz4Compressor) CompressStream(dst io.Writer, src io.Reader) error {
+	pool := loadOrStorePool(&lz4WriterPools, c.level, func() interface{} {
+		writer := lz4.NewWriter(io.Discard)
+		if err := writer.Apply(lz4.CompressionLevelOption(c.mappingLevel())); err != nil {
+			panic(err)
+		}
+		return writer
+	})
+	writer := pool.Get().(*lz4.Writer)
+	defer pool.Put(writer)
+	writer.Reset(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+var lz4ReaderPool = sync.Pool{
+	New: func() interface{} { return lz4.NewReader(nil) },
+}
+
+// DecompressStream decompresses src into dst using a pooled *lz4.Reader.
+func (c *Lz4Compressor) DecompressStream(dst io.Writer, src io.Reader) error {
+	reader := lz4ReaderPool.Get().(*lz4.Reader)
+	defer lz4ReaderPool.Put(reader)
+	reader.Reset(src)
+	_, err := io.Copy(dst, reader)
+	return err
+}
+
+// Reset is a no-op for Lz4Compressor: CompressStream already checks its
+// pooled writer back in after each call.
+func (c *Lz4Compressor) Reset() {}
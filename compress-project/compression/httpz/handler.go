@@ -0,0 +1,97 @@
+package httpz
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"compression-project/compression"
+)
+
+// Handler wraps an http.Handler, decompressing request bodies whose
+// Content-Encoding this package's codecs recognize, and compressing the
+// response body with whichever of Preferred the client's Accept-Encoding
+// header accepts best (via compression.NegotiateEncoding), setting
+// Content-Encoding to match.
+type Handler struct {
+	Next http.Handler
+	// Preferred lists the codecs this server is willing to compress
+	// responses with, most preferred first.
+	Preferred []compression.CompressionType
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if enc := r.Header.Get("Content-Encoding"); enc != "" {
+		compressor, err := compression.ForContentEncoding(enc)
+		if err != nil {
+			http.Error(w, "unsupported Content-Encoding: "+enc, http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "reading request body", http.StatusBadRequest)
+			return
+		}
+		decompressed, err := compressor.Decompress(body)
+		if err != nil {
+			http.Error(w, "invalid "+enc+" request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(decompressed))
+		r.ContentLength = int64(len(decompressed))
+		r.Header.Del("Content-Encoding")
+	}
+
+	ct := compression.NegotiateEncoding(r.Header.Get("Accept-Encoding"), h.Preferred)
+	if ct == "" {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+	compressor, err := compression.ForContentEncoding(compression.Token(ct))
+	if err != nil {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := &responseRecorder{header: w.Header()}
+	h.Next.ServeHTTP(rec, r)
+
+	compressed, err := compressor.Compress(rec.body.Bytes())
+	if err != nil {
+		// Compression failed; fall back to the uncompressed response rather
+		// than failing the request outright.
+		w.WriteHeader(rec.status())
+		w.Write(rec.body.Bytes())
+		return
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Encoding", compression.Token(ct))
+	w.Header().Set("Content-Length", strconv.Itoa(len(compressed)))
+	w.WriteHeader(rec.status())
+	w.Write(compressed)
+}
+
+// responseRecorder buffers a handler's response body so it can be compressed
+// as a whole before any bytes reach the real http.ResponseWriter; Header()
+// still returns the real response's header map so the wrapped handler's
+// headers (Content-Type, etc.) pass through untouched.
+type responseRecorder struct {
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) Write(p []byte) (int, error) { return r.body.Write(p) }
+
+func (r *responseRecorder) WriteHeader(status int) { r.statusCode = status }
+
+func (r *responseRecorder) status() int {
+	if r.statusCode == 0 {
+		return http.StatusOK
+	}
+	return r.statusCode
+}
@@ -0,0 +1,88 @@
+// Package httpz wraps this module's compression codecs as net/http
+// middleware, so HTTP clients and servers can use them for request/response
+// bodies instead of relying on net/http's built-in (gzip-only) support.
+package httpz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"compression-project/compression"
+)
+
+// Transport wraps an http.RoundTripper, optionally compressing outgoing
+// request bodies and transparently decompressing any response body whose
+// Content-Encoding this package's codecs recognize.
+type Transport struct {
+	// Base is the underlying RoundTripper. http.DefaultTransport is used if
+	// nil.
+	Base http.RoundTripper
+	// RequestEncoding, if set to a Content-Encoding token (e.g. "zstd",
+	// "gzip"), compresses the outgoing request body with that codec and
+	// sets the Content-Encoding header accordingly. Leave empty to send
+	// request bodies uncompressed.
+	RequestEncoding string
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	if t.RequestEncoding != "" && req.Body != nil {
+		compressor, err := compression.ForContentEncoding(t.RequestEncoding)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("httpz: reading request body: %w", err)
+		}
+		compressed, err := compressor.Compress(body)
+		if err != nil {
+			return nil, fmt.Errorf("httpz: compressing request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(compressed))
+		req.ContentLength = int64(len(compressed))
+		req.Header.Set("Content-Encoding", t.RequestEncoding)
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := resp.Header.Get("Content-Encoding")
+	if enc == "" {
+		return resp, nil
+	}
+	compressor, err := compression.ForContentEncoding(enc)
+	if err != nil {
+		// Unrecognized encoding: hand the caller the raw body rather than
+		// failing the round trip outright.
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("httpz: reading response body: %w", err)
+	}
+	decompressed, err := compressor.Decompress(body)
+	if err != nil {
+		return nil, fmt.Errorf("httpz: decompressing response body (%s): %w", enc, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(decompressed))
+	resp.ContentLength = int64(len(decompressed))
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
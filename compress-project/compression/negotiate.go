@@ -0,0 +1,115 @@
+package compression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Token returns the HTTP Content-Encoding / Accept-Encoding token for ct, the
+// inverse of the mapping ForContentEncoding uses to resolve a Compressor.
+func Token(ct CompressionType) string {
+	switch ct {
+	case Gzip:
+		return "gzip"
+	case Brotli:
+		return "br"
+	case Zstd, ZstdDict, ZstdParallel:
+		return "zstd"
+	case Deflate:
+		return "deflate"
+	case Snappy:
+		return "x-snappy-framed"
+	default:
+		return string(ct)
+	}
+}
+
+// ForContentEncoding maps an HTTP Content-Encoding / Accept-Encoding token to
+// a ready-to-use Compressor backed by this package's codecs, using a
+// reasonable default level for each one. Recognized tokens are "gzip", "br",
+// "zstd", "deflate" and "x-snappy-framed" (Snappy has no registered
+// Content-Encoding token, so the framing-format name is used instead).
+func ForContentEncoding(enc string) (Compressor, error) {
+	switch strings.ToLower(strings.TrimSpace(enc)) {
+	case "gzip":
+		return CompressorFactory(Gzip, 2, nil)
+	case "br":
+		return CompressorFactory(Brotli, 5, nil)
+	case "zstd":
+		return CompressorFactory(Zstd, 2, nil)
+	case "deflate":
+		return CompressorFactory(Deflate, 2, nil)
+	case "x-snappy-framed":
+		return CompressorFactory(Snappy, 0, nil)
+	default:
+		return nil, fmt.Errorf("unsupported content-encoding %q", enc)
+	}
+}
+
+// acceptedEncoding is one comma-separated entry of an Accept-Encoding
+// header, e.g. "gzip;q=0.8".
+type acceptedEncoding struct {
+	token string
+	q     float64
+}
+
+func parseAcceptEncoding(header string) []acceptedEncoding {
+	var accepted []acceptedEncoding
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		token, params, hasParams := strings.Cut(part, ";")
+		entry := acceptedEncoding{token: strings.ToLower(strings.TrimSpace(token)), q: 1.0}
+		if hasParams {
+			for _, param := range strings.Split(params, ";") {
+				name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || strings.TrimSpace(name) != "q" {
+					continue
+				}
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					entry.q = q
+				}
+			}
+		}
+		accepted = append(accepted, entry)
+	}
+	return accepted
+}
+
+// NegotiateEncoding parses an Accept-Encoding header and returns whichever
+// CompressionType in preferred the client accepts with the highest q-value
+// (ties broken by preferred's order). It returns "" if the header rejects
+// every entry in preferred (q=0) or none of them are mentioned and "*" is
+// absent or also rejected.
+func NegotiateEncoding(acceptHeader string, preferred []CompressionType) CompressionType {
+	accepted := parseAcceptEncoding(acceptHeader)
+	qFor := func(token string) (float64, bool) {
+		for _, a := range accepted {
+			if a.token == token {
+				return a.q, true
+			}
+		}
+		return 0, false
+	}
+
+	var best CompressionType
+	bestQ := 0.0
+	for _, ct := range preferred {
+		q, ok := qFor(Token(ct))
+		if !ok {
+			if starQ, starOK := qFor("*"); starOK {
+				q, ok = starQ, true
+			}
+		}
+		if !ok || q <= 0 {
+			continue
+		}
+		if best == "" || q > bestQ {
+			best, bestQ = ct, q
+		}
+	}
+	return best
+}
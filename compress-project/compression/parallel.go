@@ -0,0 +1,193 @@
+package compression
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// parallelMagic identifies the block framing ParallelCompressor writes ahead
+// of the compressed blocks: magic | blockCount | [blockLen]*blockCount.
+const parallelMagic = "PARZ"
+
+const defaultBlockSize = 1 << 20 // 1 MiB
+
+// ParallelCompressor wraps another Compressor and splits large inputs into
+// fixed-size blocks that are compressed (and decompressed) concurrently
+// across a worker pool, following the pattern used by the MongoDB Go
+// driver's parallel zlib mode. Block order is preserved regardless of which
+// worker finishes first, so Decompress always reassembles the original byte
+// stream.
+type ParallelCompressor struct {
+	inner     Compressor
+	blockSize int
+	workers   int
+}
+
+// NewParallelCompressor wraps inner so Compress/Decompress operate on
+// blockSize-byte blocks spread across workers goroutines. blockSize <= 0
+// defaults to 1 MiB; workers <= 0 defaults to runtime.GOMAXPROCS(0).
+func NewParallelCompressor(inner Compressor, blockSize int, workers int) (*ParallelCompressor, error) {
+	if inner == nil {
+		return nil, errors.New("parallel compressor requires an inner Compressor")
+	}
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &ParallelCompressor{inner: inner, blockSize: blockSize, workers: workers}, nil
+}
+
+func (p *ParallelCompressor) Compress(data []byte) ([]byte, error) {
+	blocks, err := p.runParallel(splitBlocks(data, p.blockSize), p.inner.Compress)
+	if err != nil {
+		return nil, err
+	}
+	return frameBlocks(blocks), nil
+}
+
+func (p *ParallelCompressor) Decompress(data []byte) ([]byte, error) {
+	blocks, err := unframeBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := p.runParallel(blocks, p.inner.Decompress)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	for _, block := range decompressed {
+		out.Write(block)
+	}
+	return out.Bytes(), nil
+}
+
+type indexedBlock struct {
+	index int
+	data  []byte
+	err   error
+}
+
+// runParallel runs fn over each of blocks across p.workers goroutines and
+// returns the results in the same order as blocks, regardless of completion
+// order.
+func (p *ParallelCompressor) runParallel(blocks [][]byte, fn func([]byte) ([]byte, error)) ([][]byte, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	workers := p.workers
+	if workers > len(blocks) {
+		workers = len(blocks)
+	}
+
+	jobs := make(chan int)
+	results := make(chan indexedBlock, len(blocks))
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out, err := fn(blocks[i])
+				results <- indexedBlock{index: i, data: out, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range blocks {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	ordered := make([][]byte, len(blocks))
+	var firstErr error
+	for res := range results {
+		if res.err != nil && firstErr == nil {
+			firstErr = res.err
+		}
+		ordered[res.index] = res.data
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ordered, nil
+}
+
+func splitBlocks(data []byte, blockSize int) [][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	blocks := make([][]byte, 0, (len(data)+blockSize-1)/blockSize)
+	for start := 0; start < len(data); start += blockSize {
+		end := start + blockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		blocks = append(blocks, data[start:end])
+	}
+	return blocks
+}
+
+// frameBlocks concatenates blocks behind a magic | blockCount | [blockLen]*N
+// header so unframeBlocks can split them back apart without re-parsing the
+// underlying codec's output.
+func frameBlocks(blocks [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(parallelMagic)
+
+	var word [4]byte
+	binary.BigEndian.PutUint32(word[:], uint32(len(blocks)))
+	buf.Write(word[:])
+
+	for _, block := range blocks {
+		binary.BigEndian.PutUint32(word[:], uint32(len(block)))
+		buf.Write(word[:])
+	}
+	for _, block := range blocks {
+		buf.Write(block)
+	}
+	return buf.Bytes()
+}
+
+func unframeBlocks(data []byte) ([][]byte, error) {
+	if len(data) < len(parallelMagic)+4 || string(data[:len(parallelMagic)]) != parallelMagic {
+		return nil, fmt.Errorf("parallel compressor: bad magic header")
+	}
+	pos := len(parallelMagic)
+
+	count := binary.BigEndian.Uint32(data[pos : pos+4])
+	pos += 4
+
+	lengths := make([]int, count)
+	for i := range lengths {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("parallel compressor: truncated block length header")
+		}
+		lengths[i] = int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		pos += 4
+	}
+
+	blocks := make([][]byte, count)
+	for i, length := range lengths {
+		if pos+length > len(data) {
+			return nil, fmt.Errorf("parallel compressor: truncated block %d", i)
+		}
+		blocks[i] = data[pos : pos+length]
+		pos += length
+	}
+	return blocks, nil
+}